@@ -0,0 +1,252 @@
+package api
+
+import (
+	"container/list"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+)
+
+// cardinalityWindow is the sliding window over which distinct values are
+// counted for a given (service, tag key) pair.
+const cardinalityWindow = time.Minute
+
+// defaultCardinalityThreshold is the default number of distinct values
+// allowed for a single (service, tag key) pair per cardinalityWindow.
+const defaultCardinalityThreshold = 1000
+
+// highCardinalitySentinel replaces novel values once a key's distinct-value
+// budget for the current window has been exhausted.
+const highCardinalitySentinel = "<high_cardinality>"
+
+// hllRegisters is the register count of the bounded HyperLogLog sketch used
+// to estimate distinct values per key; it bounds memory per key regardless
+// of how many distinct values actually flow through it.
+const hllRegisters = 256
+
+// lruSize is how many of the most recently seen exact values are kept per
+// key, so a value seen repeatedly in a row isn't double-counted against
+// the sketch and doesn't flap in and out of the sentinel.
+const lruSize = 128
+
+// maxTrackedKeys bounds how many distinct (service, tag key) pairs a
+// CardinalityLimiter keeps a sketch for at once. Since both a span's service
+// and its Meta keys are attacker-controlled and otherwise unbounded in
+// number, the least-recently-used pair is evicted once this limit is
+// reached, so the limiter's own memory stays bounded regardless of how many
+// distinct pairs a client sends.
+const maxTrackedKeys = 100000
+
+// cardinalitySketch estimates the number of distinct values seen for one
+// (service, tag key) pair over the current window, backed by a small
+// HyperLogLog-style register array plus an LRU of recently seen exact
+// values.
+type cardinalitySketch struct {
+	mu        sync.Mutex
+	registers [hllRegisters]uint8
+	lru       *list.List
+	lruIndex  map[string]*list.Element
+	windowEnd time.Time
+}
+
+func newCardinalitySketch(now time.Time) *cardinalitySketch {
+	return &cardinalitySketch{
+		lru:       list.New(),
+		lruIndex:  make(map[string]*list.Element, lruSize),
+		windowEnd: now.Add(cardinalityWindow),
+	}
+}
+
+// observe records v, rolling over the sketch if the current window has
+// elapsed, and returns the estimated cardinality of the window containing
+// now.
+func (s *cardinalitySketch) observe(v string, now time.Time) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.windowEnd) {
+		s.registers = [hllRegisters]uint8{}
+		s.lru.Init()
+		s.lruIndex = make(map[string]*list.Element, lruSize)
+		s.windowEnd = now.Add(cardinalityWindow)
+	}
+
+	if el, ok := s.lruIndex[v]; ok {
+		s.lru.MoveToFront(el)
+	} else {
+		s.addHLL(v)
+		el := s.lru.PushFront(v)
+		s.lruIndex[v] = el
+		if s.lru.Len() > lruSize {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.lruIndex, oldest.Value.(string))
+		}
+	}
+
+	return s.estimateLocked()
+}
+
+// addHLL folds v into the sketch's registers.
+func (s *cardinalitySketch) addHLL(v string) {
+	h := fnv.New64a()
+	h.Write([]byte(v))
+	sum := h.Sum64()
+
+	idx := sum % hllRegisters
+	rest := sum / hllRegisters
+	rank := uint8(bits.TrailingZeros64(rest)) + 1
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// estimateLocked returns the sketch's current cardinality estimate. Callers
+// must hold s.mu.
+func (s *cardinalitySketch) estimateLocked() uint64 {
+	const m = float64(hllRegisters)
+
+	var sum float64
+	zeros := 0
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate)
+}
+
+// estimate returns the sketch's current cardinality estimate.
+func (s *cardinalitySketch) estimate() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.estimateLocked()
+}
+
+// CardinalityLimiter protects the agent from unbounded-cardinality tags
+// (e.g. request IDs stuffed into Meta) by rewriting novel values to
+// highCardinalitySentinel once a (service, tag key) pair has seen more than
+// its threshold of distinct values within cardinalityWindow. It is safe for
+// concurrent use by many receiver goroutines.
+type CardinalityLimiter struct {
+	threshold uint64 // accessed atomically
+
+	mu       sync.Mutex
+	sketches map[string]*cardinalitySketch
+	// lru and lruIndex track (service, tag key) pairs in least-recently-used
+	// order so the oldest can be evicted once sketches grows past
+	// maxTrackedKeys, bounding the map's size independently of any single
+	// sketch's own window.
+	lru      *list.List
+	lruIndex map[string]*list.Element
+}
+
+// NewCardinalityLimiter returns a CardinalityLimiter allowing up to
+// threshold distinct values per (service, tag key) pair per minute. A
+// threshold of 0 uses defaultCardinalityThreshold.
+func NewCardinalityLimiter(threshold uint64) *CardinalityLimiter {
+	l := &CardinalityLimiter{
+		sketches: make(map[string]*cardinalitySketch),
+		lru:      list.New(),
+		lruIndex: make(map[string]*list.Element),
+	}
+	l.SetThreshold(threshold)
+	return l
+}
+
+// SetThreshold updates the limiter's distinct-value threshold. A threshold
+// of 0 resets it to defaultCardinalityThreshold.
+func (l *CardinalityLimiter) SetThreshold(threshold uint64) {
+	if threshold == 0 {
+		threshold = defaultCardinalityThreshold
+	}
+	atomic.StoreUint64(&l.threshold, threshold)
+}
+
+// cardinalityKey combines service and tagKey into a single map key. It
+// length-prefixes service so that, unlike a fixed separator, no value either
+// string contains can cause two distinct (service, tagKey) pairs to collide
+// onto the same key.
+func cardinalityKey(service, tagKey string) string {
+	return strconv.Itoa(len(service)) + ":" + service + tagKey
+}
+
+// evictOldestLocked removes the least-recently-used (service, tag key) pair
+// from l.sketches. Callers must hold l.mu.
+func (l *CardinalityLimiter) evictOldestLocked() {
+	oldest := l.lru.Back()
+	if oldest == nil {
+		return
+	}
+	key := oldest.Value.(string)
+	l.lru.Remove(oldest)
+	delete(l.lruIndex, key)
+	delete(l.sketches, key)
+}
+
+// Allow records that service saw value v for tagKey. It returns v unchanged
+// if the key is still within its cardinality budget, or
+// highCardinalitySentinel (and ok=true) if the budget has been exceeded.
+func (l *CardinalityLimiter) Allow(service, tagKey, v string) (out string, limited bool) {
+	now := time.Now()
+	key := cardinalityKey(service, tagKey)
+
+	l.mu.Lock()
+	sk, ok := l.sketches[key]
+	if !ok {
+		sk = newCardinalitySketch(now)
+		l.sketches[key] = sk
+		l.lruIndex[key] = l.lru.PushFront(key)
+		if len(l.sketches) > maxTrackedKeys {
+			l.evictOldestLocked()
+		}
+	} else {
+		l.lru.MoveToFront(l.lruIndex[key])
+	}
+	l.mu.Unlock()
+
+	count := sk.observe(v, now)
+	if count > atomic.LoadUint64(&l.threshold) {
+		return highCardinalitySentinel, true
+	}
+	return v, false
+}
+
+// Counts returns, for every (service, tag key) pair currently tracked, the
+// estimated number of distinct values seen in its current window. It backs
+// the per-key counters surfaced on the agent status page.
+func (l *CardinalityLimiter) Counts() map[string]uint64 {
+	l.mu.Lock()
+	snapshot := make(map[string]*cardinalitySketch, len(l.sketches))
+	for k, sk := range l.sketches {
+		snapshot[k] = sk
+	}
+	l.mu.Unlock()
+
+	out := make(map[string]uint64, len(snapshot))
+	for k, sk := range snapshot {
+		out[k] = sk.estimate()
+	}
+	return out
+}
+
+// globalCardinalityLimiter is the limiter normalize uses; its threshold
+// tracks the active NormalizationConfig.
+var globalCardinalityLimiter = NewCardinalityLimiter(0)
+
+func init() {
+	info.RegisterCardinalityProvider(globalCardinalityLimiter.Counts)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardinalityLimiterAllowsUnderThreshold(t *testing.T) {
+	l := NewCardinalityLimiter(10)
+
+	for i := 0; i < 10; i++ {
+		v := fmt.Sprintf("req-%d", i)
+		out, limited := l.Allow("django", "request.id", v)
+		assert.False(t, limited)
+		assert.Equal(t, v, out)
+	}
+}
+
+func TestCardinalityLimiterRewritesOverThreshold(t *testing.T) {
+	l := NewCardinalityLimiter(10)
+
+	for i := 0; i < 10; i++ {
+		l.Allow("django", "request.id", fmt.Sprintf("req-%d", i))
+	}
+
+	out, limited := l.Allow("django", "request.id", "req-overflow")
+	assert.True(t, limited)
+	assert.Equal(t, highCardinalitySentinel, out)
+}
+
+func TestCardinalityLimiterIsPerServiceAndKey(t *testing.T) {
+	l := NewCardinalityLimiter(1)
+
+	l.Allow("django", "request.id", "a")
+	out, limited := l.Allow("django", "session.id", "b")
+	assert.False(t, limited)
+	assert.Equal(t, "b", out)
+
+	out, limited = l.Allow("checkout", "request.id", "c")
+	assert.False(t, limited)
+	assert.Equal(t, "c", out)
+}
+
+func TestCardinalityLimiterRepeatedValueDoesNotCount(t *testing.T) {
+	l := NewCardinalityLimiter(1)
+
+	l.Allow("django", "request.id", "a")
+	out, limited := l.Allow("django", "request.id", "a")
+	assert.False(t, limited)
+	assert.Equal(t, "a", out)
+}
+
+func TestCardinalityLimiterCounts(t *testing.T) {
+	l := NewCardinalityLimiter(10)
+	l.Allow("django", "request.id", "a")
+	l.Allow("django", "request.id", "b")
+	l.Allow("checkout", "session.id", "c")
+
+	counts := l.Counts()
+	assert.Equal(t, uint64(2), counts[cardinalityKey("django", "request.id")])
+	assert.Equal(t, uint64(1), counts[cardinalityKey("checkout", "session.id")])
+}
+
+func TestCardinalityLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+	l := NewCardinalityLimiter(10)
+	for i := 0; i < maxTrackedKeys; i++ {
+		l.Allow("django", fmt.Sprintf("tag-%d", i), "v")
+	}
+	assert.Len(t, l.sketches, maxTrackedKeys)
+
+	// tag-0 is now the least recently used; pushing one more distinct key
+	// should evict it rather than grow the map further.
+	l.Allow("django", "tag-overflow", "v")
+	assert.Len(t, l.sketches, maxTrackedKeys)
+	assert.NotContains(t, l.sketches, cardinalityKey("django", "tag-0"))
+	assert.Contains(t, l.sketches, cardinalityKey("django", "tag-overflow"))
+}
+
+func TestGlobalCardinalityLimiterRegisteredWithInfo(t *testing.T) {
+	globalCardinalityLimiter.mu.Lock()
+	globalCardinalityLimiter.sketches = make(map[string]*cardinalitySketch)
+	globalCardinalityLimiter.lru = list.New()
+	globalCardinalityLimiter.lruIndex = make(map[string]*list.Element)
+	globalCardinalityLimiter.mu.Unlock()
+
+	globalCardinalityLimiter.Allow("django", "request.id", "a")
+
+	counts := info.CardinalityCounts()
+	assert.Equal(t, uint64(1), counts[cardinalityKey("django", "request.id")])
+}
+
+func TestNormalizeHighCardinality(t *testing.T) {
+	defer SetNormalizationConfig(nil) // restore defaults
+
+	cfg := defaultNormalizationConfig()
+	cfg.CardinalityThreshold = 1
+	SetNormalizationConfig(cfg)
+	globalCardinalityLimiter.sketches = make(map[string]*cardinalitySketch)
+	globalCardinalityLimiter.lru = list.New()
+	globalCardinalityLimiter.lruIndex = make(map[string]*list.Element)
+
+	s1 := newTestSpan()
+	s1.Meta["request.id"] = "first"
+	assert.NoError(t, normalize(&info.TagStats{}, s1))
+
+	ts2 := &info.TagStats{}
+	s2 := newTestSpan()
+	s2.Meta["request.id"] = "second"
+	assert.NoError(t, normalize(ts2, s2))
+	assert.Equal(t, highCardinalitySentinel, s2.Meta["request.id"])
+	assert.Equal(t, onlyIssue("tag_high_cardinality"), statsToIssues(ts2))
+}
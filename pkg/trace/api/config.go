@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v2"
+)
+
+// NormalizationConfig holds the tunable limits and rules applied by
+// normalize and normalizeTrace. Operators can override it per-agent by
+// loading a YAML or JSON file via LoadNormalizationConfig; anything left
+// unset falls back to the hard-coded defaults this package has always used.
+type NormalizationConfig struct {
+	// MaxServiceLen is the maximum length, in bytes, a service name can have.
+	MaxServiceLen int `json:"max_service_len" yaml:"max_service_len"`
+	// MaxNameLen is the maximum length, in bytes, a span name can have.
+	MaxNameLen int `json:"max_name_len" yaml:"max_name_len"`
+	// MaxTypeLen is the maximum length, in bytes, a span type can have.
+	MaxTypeLen int `json:"max_type_len" yaml:"max_type_len"`
+	// MaxResourceLen is the maximum length, in bytes, a resource can have.
+	MaxResourceLen int `json:"max_resource_len" yaml:"max_resource_len"`
+	// MaxTagLen is the maximum length, in runes, a normalized tag can have.
+	MaxTagLen int `json:"max_tag_len" yaml:"max_tag_len"`
+	// DefaultServiceName is used when a span has no, or no allowed, service name.
+	DefaultServiceName string `json:"default_service_name" yaml:"default_service_name"`
+	// DefaultSpanName is used when a span has no, or no valid, name.
+	DefaultSpanName string `json:"default_span_name" yaml:"default_span_name"`
+	// EnvLowercase controls whether the env tag is lowercased, as it always has been.
+	EnvLowercase bool `json:"env_lowercase" yaml:"env_lowercase"`
+	// ServiceAllowlist, if non-empty, restricts accepted service names to
+	// those matching at least one of these regular expressions; anything
+	// else is treated as if the service name were empty.
+	ServiceAllowlist []string `json:"service_allowlist" yaml:"service_allowlist"`
+	// TagRenames lets operators add extra tag-key rename rules, applied
+	// before the built-in semantic-convention renames.
+	TagRenames map[string]string `json:"tag_renames" yaml:"tag_renames"`
+	// PIIScrubbing enables the regex-based PII scrubber that runs on
+	// Resource and Meta before they're fixed up and truncated.
+	PIIScrubbing bool `json:"pii_scrubbing" yaml:"pii_scrubbing"`
+	// PIIRules is the set of regex/replacement rules applied by the
+	// scrubber, in order. Overriding it replaces the built-in rule set
+	// entirely rather than appending to it.
+	PIIRules []PIIRule `json:"pii_rules" yaml:"pii_rules"`
+	// PIIBudgetMicros bounds, in microseconds, how long the scrubber may
+	// spend on a single span before bailing out early.
+	PIIBudgetMicros int `json:"pii_budget_micros" yaml:"pii_budget_micros"`
+	// CardinalityEnabled controls whether the per-tag cardinality limiter
+	// rewrites high-cardinality tag values to a sentinel.
+	CardinalityEnabled bool `json:"cardinality_enabled" yaml:"cardinality_enabled"`
+	// CardinalityThreshold is how many distinct values a single (service,
+	// tag key) pair may have per minute before new values are rewritten.
+	CardinalityThreshold int `json:"cardinality_threshold" yaml:"cardinality_threshold"`
+
+	serviceAllowlistRe []*regexp.Regexp
+}
+
+// defaultNormalizationConfig returns the configuration matching normalize's
+// historical, hard-coded behavior.
+func defaultNormalizationConfig() *NormalizationConfig {
+	return &NormalizationConfig{
+		MaxServiceLen:        MaxServiceLen,
+		MaxNameLen:           MaxNameLen,
+		MaxTypeLen:           MaxTypeLen,
+		MaxResourceLen:       MaxResourceLen,
+		MaxTagLen:            MaxTagLen,
+		DefaultServiceName:   DefaultServiceName,
+		DefaultSpanName:      DefaultSpanName,
+		EnvLowercase:         true,
+		PIIScrubbing:         true,
+		PIIRules:             defaultPIIRules(),
+		PIIBudgetMicros:      defaultPIIBudgetMicros,
+		CardinalityEnabled:   true,
+		CardinalityThreshold: defaultCardinalityThreshold,
+	}
+}
+
+// normalizationConfig holds the NormalizationConfig currently used by
+// normalize and normalizeTag. It defaults to defaultNormalizationConfig and
+// is swapped atomically so normalization stays safe for concurrent use.
+var normalizationConfig atomic.Value
+
+func init() {
+	cfg := defaultNormalizationConfig()
+	normalizationConfig.Store(cfg)
+	globalCardinalityLimiter.SetThreshold(uint64(cfg.CardinalityThreshold))
+}
+
+// SetNormalizationConfig compiles cfg's regular expressions and installs it
+// as the configuration used by future calls to normalize and normalizeTrace.
+// The receiver calls this once at startup, after loading the operator's
+// config file, if any. If cfg fails to compile, the previously installed
+// configuration is left in place and the compile error is returned so the
+// caller can log it.
+func SetNormalizationConfig(cfg *NormalizationConfig) error {
+	if cfg == nil {
+		cfg = defaultNormalizationConfig()
+	}
+	if err := cfg.compile(); err != nil {
+		return err
+	}
+	normalizationConfig.Store(cfg)
+	globalCardinalityLimiter.SetThreshold(uint64(cfg.CardinalityThreshold))
+	return nil
+}
+
+// currentNormalizationConfig returns the configuration currently in effect.
+func currentNormalizationConfig() *NormalizationConfig {
+	return normalizationConfig.Load().(*NormalizationConfig)
+}
+
+// LoadNormalizationConfig reads a NormalizationConfig from path. The file
+// may be either YAML or JSON: it is unmarshalled as YAML first (a superset
+// of JSON), then re-marshalled to JSON and decoded into NormalizationConfig,
+// the same trick used elsewhere in the agent to accept both formats through
+// a single set of `json:` tags. Fields absent from the file keep their
+// default value.
+func LoadNormalizationConfig(path string) (*NormalizationConfig, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("normalization config: %v", err)
+	}
+
+	var generic interface{}
+	if err := yaml.Unmarshal(raw, &generic); err != nil {
+		return nil, fmt.Errorf("normalization config: %v", err)
+	}
+	asJSON, err := json.Marshal(stringifyYAMLKeys(generic))
+	if err != nil {
+		return nil, fmt.Errorf("normalization config: %v", err)
+	}
+
+	cfg := defaultNormalizationConfig()
+	if err := json.Unmarshal(asJSON, cfg); err != nil {
+		return nil, fmt.Errorf("normalization config: %v", err)
+	}
+	if err := cfg.compile(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// stringifyYAMLKeys recursively converts the map[interface{}]interface{}
+// values produced by YAML unmarshalling into map[string]interface{}, which
+// is what encoding/json requires.
+func stringifyYAMLKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprintf("%v", k)] = stringifyYAMLKeys(val)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = stringifyYAMLKeys(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// compile precompiles the service allowlist and PII rule regular expressions.
+func (c *NormalizationConfig) compile() error {
+	c.serviceAllowlistRe = nil
+	for _, pattern := range c.ServiceAllowlist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("normalization config: invalid service_allowlist pattern %q: %v", pattern, err)
+		}
+		c.serviceAllowlistRe = append(c.serviceAllowlistRe, re)
+	}
+	for i, rule := range c.PIIRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("normalization config: invalid pii_rules[%d] (%s) pattern: %v", i, rule.Name, err)
+		}
+		c.PIIRules[i].re = re
+	}
+	return nil
+}
+
+// allowsService reports whether svc is permitted by the configured
+// allowlist. An empty allowlist permits everything.
+func (c *NormalizationConfig) allowsService(svc string) bool {
+	if len(c.serviceAllowlistRe) == 0 {
+		return true
+	}
+	for _, re := range c.serviceAllowlistRe {
+		if re.MatchString(svc) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// foldHexID decodes a hex-encoded trace/span ID, as used by Zipkin v2 JSON
+// and OTLP/JSON, into the uint64 ID model the agent uses internally. IDs are
+// 8 or 16 bytes (64 or 128 bits); 128-bit IDs are folded down to their low
+// 64 bits, and truncated reports whether any high bits were discarded in
+// the process.
+func foldHexID(hexID string) (id uint64, truncated bool, err error) {
+	raw, err := hex.DecodeString(hexID)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid hex ID %q: %v", hexID, err)
+	}
+	switch len(raw) {
+	case 8:
+		return binary.BigEndian.Uint64(raw), false, nil
+	case 16:
+		hi := binary.BigEndian.Uint64(raw[:8])
+		lo := binary.BigEndian.Uint64(raw[8:])
+		return lo, hi != 0, nil
+	default:
+		return 0, false, fmt.Errorf("invalid ID length for %q: %d bytes", hexID, len(raw))
+	}
+}
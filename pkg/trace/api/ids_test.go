@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldHexID64bit(t *testing.T) {
+	id, truncated, err := foldHexID("000000000000162e")
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, uint64(0x162e), id)
+}
+
+func TestFoldHexID128bit(t *testing.T) {
+	hi := "0000000000000001"
+	lo := "000000000000002a"
+	id, truncated, err := foldHexID(hi + lo)
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, uint64(0x2a), id)
+}
+
+func TestFoldHexID128bitNoTruncation(t *testing.T) {
+	hi := "0000000000000000"
+	lo := "000000000000162e"
+	id, truncated, err := foldHexID(hi + lo)
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, uint64(0x162e), id)
+}
+
+func TestFoldHexIDInvalid(t *testing.T) {
+	_, _, err := foldHexID("not-hex")
+	assert.Error(t, err)
+
+	_, _, err = foldHexID("abcd") // neither 8 nor 16 bytes
+	assert.Error(t, err)
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+const (
+	// MaxNameLen the maximum length a span name can have
+	MaxNameLen = 100
+	// MaxServiceLen the maximum length a service can have
+	MaxServiceLen = 100
+	// MaxTypeLen the maximum length a span type can have
+	MaxTypeLen = 100
+	// MaxResourceLen the maximum length a resource can have
+	MaxResourceLen = 5000
+	// MaxTagLen the maximum length a tag can have
+	MaxTagLen = 200
+
+	// DefaultSpanName is the default name used for a span when one is not set or invalid.
+	DefaultSpanName = "unnamed_operation"
+	// DefaultServiceName is the default name used for a service when one is not set or invalid.
+	DefaultServiceName = "unnamed-service"
+)
+
+// normalize makes sure a span is properly initialized and sanitizes its
+// fields, returning an error if the span is beyond repair and must be
+// dropped entirely.
+func normalize(ts *info.TagStats, s *pb.Span) error {
+	if s.TraceID == 0 {
+		ts.TracesDropped.TraceIDZero++
+		return fmt.Errorf("TraceID is zero (reason:trace_id_zero): %v", s)
+	}
+	if s.SpanID == 0 {
+		ts.TracesDropped.SpanIDZero++
+		return fmt.Errorf("SpanID is zero (reason:span_id_zero): %v", s)
+	}
+
+	// Zipkin clients can send a root span whose parent, trace and span ID
+	// are all equal; this is not a real parent relationship.
+	if s.ParentID != 0 && s.ParentID == s.TraceID && s.ParentID == s.SpanID {
+		s.ParentID = 0
+	}
+
+	conf := currentNormalizationConfig()
+
+	// Scrub sensitive data before Resource/Meta are fixed up for UTF-8 and
+	// truncated, so redacted strings are still subject to those limits.
+	scrubPII(ts, conf, s)
+
+	s.Service = normalizeTag(s.Service)
+	if s.Service == "" || !conf.allowsService(s.Service) {
+		ts.TracesMalformed.ServiceEmpty++
+		if ts.Lang != "" {
+			s.Service = ts.Lang
+		} else {
+			s.Service = conf.DefaultServiceName
+		}
+	} else if len(s.Service) > conf.MaxServiceLen {
+		ts.TracesMalformed.ServiceTruncate++
+		s.Service = s.Service[:conf.MaxServiceLen]
+	}
+
+	if s.Name == "" {
+		ts.TracesMalformed.SpanNameEmpty++
+		s.Name = conf.DefaultSpanName
+	} else {
+		name, ok := normalizeName(s.Name)
+		if !ok {
+			ts.TracesMalformed.SpanNameInvalid++
+			s.Name = conf.DefaultSpanName
+		} else {
+			if len(name) > conf.MaxNameLen {
+				ts.TracesMalformed.SpanNameTruncate++
+				name = name[:conf.MaxNameLen]
+			}
+			s.Name = name
+		}
+	}
+
+	if s.Resource == "" {
+		ts.TracesMalformed.ResourceEmpty++
+		s.Resource = s.Name
+	} else {
+		s.Resource = fixUTF8(s.Resource)
+		if len(s.Resource) > conf.MaxResourceLen {
+			s.Resource = s.Resource[:conf.MaxResourceLen]
+		}
+	}
+
+	if s.Start < minValidStartDate {
+		ts.TracesMalformed.InvalidStartDate++
+	}
+
+	if s.Duration < 0 {
+		ts.TracesMalformed.InvalidDuration++
+		s.Duration = 0
+	}
+
+	if s.Type != "" {
+		s.Type = fixUTF8(s.Type)
+		if len(s.Type) > conf.MaxTypeLen {
+			ts.TracesMalformed.TypeTruncate++
+			s.Type = s.Type[:conf.MaxTypeLen]
+		}
+	}
+
+	if env, ok := s.Meta["env"]; ok && conf.EnvLowercase {
+		s.Meta["env"] = normalizeTag(env)
+	}
+
+	if len(s.Meta) > 0 {
+		fixed := make(map[string]string, len(s.Meta))
+		for k, v := range s.Meta {
+			if renamed, ok := conf.TagRenames[k]; ok {
+				k = renamed
+			}
+			fixed[fixUTF8(k)] = fixUTF8(v)
+		}
+		s.Meta = fixed
+	}
+
+	applySemanticConventions(ts, s)
+
+	// This runs after applySemanticConventions so that legacy keys (e.g.
+	// httpStatusCode vs. http.status) are tracked as a single, canonical
+	// (service, tag key) pair rather than one bucket per alias.
+	if conf.CardinalityEnabled {
+		limited := false
+		for k, v := range s.Meta {
+			if out, hit := globalCardinalityLimiter.Allow(s.Service, k, v); hit {
+				s.Meta[k] = out
+				limited = true
+			}
+		}
+		if limited {
+			ts.TracesMalformed.TagHighCardinality++
+		}
+	}
+
+	return nil
+}
+
+// minValidStartDate is a lower bound used to flag spans whose Start looks
+// like it isn't a real Unix nanosecond timestamp (e.g. left at its zero
+// value, or mistakenly expressed in a different unit).
+const minValidStartDate = 1e17 // 2001-09-09, in nanoseconds
+
+// normalizeTrace validates a trace and drops it entirely if it cannot be
+// salvaged (e.g. it's empty or spans disagree on their trace ID). Spans that
+// are individually invalid are normalized in place by normalize, which never
+// drops an otherwise well-formed trace.
+func normalizeTrace(ts *info.TagStats, t pb.Trace) error {
+	if len(t) == 0 {
+		ts.TracesDropped.EmptyTrace++
+		return errors.New("trace is empty (reason:empty_trace)")
+	}
+
+	traceID := t[0].TraceID
+	spanIDs := make(map[uint64]struct{}, len(t))
+
+	for _, s := range t {
+		if s.TraceID != traceID {
+			ts.TracesDropped.ForeignSpan++
+			return fmt.Errorf("span has foreign trace ID (reason:foreign_span): %v", s)
+		}
+		if _, ok := spanIDs[s.SpanID]; ok && s.SpanID != 0 {
+			ts.TracesMalformed.DuplicateSpanID++
+			s.SpanID = rand.Uint64()
+		}
+		spanIDs[s.SpanID] = struct{}{}
+
+		if err := normalize(ts, s); err != nil {
+			continue
+		}
+	}
+
+	return nil
+}
+
+// normalizeName normalizes a span name, lowercasing it and replacing any
+// character that isn't alphanumeric or a dot with an underscore. It reports
+// whether the name contained any alphanumeric character at all.
+func normalizeName(name string) (string, bool) {
+	var b strings.Builder
+	lastUnderscore := false
+	any := false
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.':
+			b.WriteRune(unicode.ToLower(r))
+			lastUnderscore = false
+			any = true
+		case !lastUnderscore:
+			b.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	if !any {
+		return "", false
+	}
+	return strings.Trim(b.String(), "_"), true
+}
+
+// normalizeTag normalizes a tag/service value: it is lowercased, truncated
+// to the configured MaxTagLen runes, stripped of any leading run of
+// characters that are neither a unicode letter nor a colon, and has every
+// other run of non-alphanumeric characters (besides '.', '/', ':' and '-')
+// collapsed into a single underscore. Trailing underscores are never emitted.
+func normalizeTag(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	maxLen := currentNormalizationConfig().MaxTagLen
+
+	var b strings.Builder
+	started := false
+	sepPending := false
+	count := 0
+
+	for i := 0; i < len(v) && count < maxLen; count++ {
+		r, size := utf8.DecodeRuneInString(v[i:])
+		i += size
+
+		if !started {
+			if unicode.IsLetter(r) || r == ':' {
+				started = true
+			} else {
+				continue
+			}
+		}
+
+		if isTagLiteral(r) {
+			if sepPending && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			sepPending = false
+		} else {
+			sepPending = true
+		}
+	}
+
+	return b.String()
+}
+
+// isTagLiteral reports whether r is kept as-is (modulo lowercasing) by
+// normalizeTag, as opposed to being collapsed into a separator.
+func isTagLiteral(r rune) bool {
+	switch r {
+	case '.', '/', ':', '-':
+		return true
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// fixUTF8 replaces every invalid UTF-8 byte in s with the unicode
+// replacement character, leaving valid runes untouched.
+func fixUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteRune(utf8.RuneError)
+		} else {
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// isValidStatusCode reports whether the given string is a valid HTTP status
+// code, i.e. an integer in the [100, 599] range.
+func isValidStatusCode(code string) bool {
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return false
+	}
+	return n >= 100 && n <= 599
+}
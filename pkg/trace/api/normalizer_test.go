@@ -486,7 +486,10 @@ func BenchmarkNormalization(b *testing.B) {
 }
 
 func TestNormalizeTag(t *testing.T) {
-	for _, tt := range []struct{ in, out string }{
+	for _, tt := range []struct {
+		in, out   string
+		maxTagLen int // 0 uses the package default MaxTagLen
+	}{
 		{in: "#test_starting_hash", out: "test_starting_hash"},
 		{in: "TestCAPSandSuch", out: "testcapsandsuch"},
 		{in: "Test Conversion Of Weird !@#$%^&**() Characters", out: "test_conversion_of_weird_characters"},
@@ -535,11 +538,16 @@ func TestNormalizeTag(t *testing.T) {
 			}(),
 			out: "a", // 'b' should have been truncated
 		},
-		{"a" + string(unicode.ReplacementChar), "a"},
-		{"a" + string(unicode.ReplacementChar) + string(unicode.ReplacementChar), "a"},
-		{"a" + string(unicode.ReplacementChar) + string(unicode.ReplacementChar) + "b", "a_b"},
+		{in: "a" + string(unicode.ReplacementChar), out: "a"},
+		{in: "a" + string(unicode.ReplacementChar) + string(unicode.ReplacementChar), out: "a"},
+		{in: "a" + string(unicode.ReplacementChar) + string(unicode.ReplacementChar) + "b", out: "a_b"},
+		{in: "fun:ky_ta@#g/1", out: "fun:k", maxTagLen: 5},
 	} {
 		t.Run("", func(t *testing.T) {
+			if tt.maxTagLen != 0 {
+				defer SetNormalizationConfig(nil) // restore defaults
+				SetNormalizationConfig(&NormalizationConfig{MaxTagLen: tt.maxTagLen})
+			}
 			assert.Equal(t, tt.out, normalizeTag(tt.in), tt.in)
 		})
 	}
@@ -561,3 +569,154 @@ func BenchmarkNormalizeTag(b *testing.B) {
 	b.Run("plenty", benchNormalizeTag("fun:ky_ta@#g/1"))
 	b.Run("more", benchNormalizeTag("fun:k####y_ta@#g/1_@@#"))
 }
+
+func TestNormalizeServiceAllowlist(t *testing.T) {
+	defer SetNormalizationConfig(nil) // restore defaults
+
+	cfg := defaultNormalizationConfig()
+	cfg.ServiceAllowlist = []string{"^django$", "^checkout-.*$"}
+	assert.NoError(t, SetNormalizationConfig(cfg))
+
+	t.Run("allowed", func(t *testing.T) {
+		ts := &info.TagStats{}
+		s := newTestSpan()
+		s.Service = "checkout-worker"
+		assert.NoError(t, normalize(ts, s))
+		assert.Equal(t, "checkout-worker", s.Service)
+		assert.Equal(t, noIssues(), statsToIssues(ts))
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		ts := &info.TagStats{}
+		s := newTestSpan()
+		s.Service = "unknown-service"
+		assert.NoError(t, normalize(ts, s))
+		assert.Equal(t, DefaultServiceName, s.Service)
+		assert.Equal(t, onlyIssue("service_empty"), statsToIssues(ts))
+	})
+}
+
+func TestNormalizePII(t *testing.T) {
+	t.Run("resource email", func(t *testing.T) {
+		ts := &info.TagStats{}
+		s := newTestSpan()
+		s.Resource = "GET /users?email=leo@fondue.com"
+		assert.NoError(t, normalize(ts, s))
+		assert.Equal(t, "GET /users?email=?", s.Resource)
+		assert.Equal(t, onlyIssue("pii_redacted"), statsToIssues(ts))
+	})
+
+	t.Run("meta query secret", func(t *testing.T) {
+		ts := &info.TagStats{}
+		s := newTestSpan()
+		s.Meta["http.url"] = "/login?password=hunter2&user=leo"
+		assert.NoError(t, normalize(ts, s))
+		assert.Equal(t, "/login?password=?&user=leo", s.Meta["http.url"])
+		assert.Equal(t, onlyIssue("pii_redacted"), statsToIssues(ts))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		ts := &info.TagStats{}
+		s := newTestSpan()
+		assert.NoError(t, normalize(ts, s))
+		assert.Equal(t, noIssues(), statsToIssues(ts))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		defer SetNormalizationConfig(nil) // restore defaults
+
+		cfg := defaultNormalizationConfig()
+		cfg.PIIScrubbing = false
+		SetNormalizationConfig(cfg)
+
+		ts := &info.TagStats{}
+		s := newTestSpan()
+		s.Resource = "GET /users?email=leo@fondue.com"
+		assert.NoError(t, normalize(ts, s))
+		assert.Equal(t, "GET /users?email=leo@fondue.com", s.Resource)
+		assert.Equal(t, noIssues(), statsToIssues(ts))
+	})
+}
+
+func TestNormalizeSemconvRenames(t *testing.T) {
+	// The renamed value must satisfy whatever typed constraint applies to
+	// its target key, or applySemanticConventions will (correctly) reject
+	// and drop it instead of keeping the renamed tag.
+	validValues := map[string]string{
+		"http.status_code": "200",
+		"db.system":        "postgresql",
+	}
+
+	for from, to := range semconvRenames {
+		t.Run(from, func(t *testing.T) {
+			ts := &info.TagStats{}
+			s := newTestSpan()
+			s.Meta[from] = validValues[to]
+			assert.NoError(t, normalize(ts, s))
+			assert.Equal(t, validValues[to], s.Meta[to])
+			assert.NotContains(t, s.Meta, from)
+			assert.Equal(t, noIssues(), statsToIssues(ts))
+		})
+	}
+}
+
+func TestNormalizeSemconvInvalidHTTPStatus(t *testing.T) {
+	ts := &info.TagStats{}
+	s := newTestSpan()
+	s.Meta["http.status_code"] = "not-a-status"
+	assert.NoError(t, normalize(ts, s))
+	assert.NotContains(t, s.Meta, "http.status_code")
+	assert.Equal(t, onlyIssue("semconv_invalid_http_status"), statsToIssues(ts))
+}
+
+func TestNormalizeSemconvInvalidDBSystem(t *testing.T) {
+	ts := &info.TagStats{}
+	s := newTestSpan()
+	s.Meta["db.system"] = "not-a-db"
+	assert.NoError(t, normalize(ts, s))
+	assert.NotContains(t, s.Meta, "db.system")
+	assert.Equal(t, onlyIssue("semconv_invalid_db_system"), statsToIssues(ts))
+}
+
+func TestNormalizeSemconvValidDBSystem(t *testing.T) {
+	ts := &info.TagStats{}
+	s := newTestSpan()
+	s.Meta["db.system"] = "postgresql"
+	assert.NoError(t, normalize(ts, s))
+	assert.Equal(t, "postgresql", s.Meta["db.system"])
+	assert.Equal(t, noIssues(), statsToIssues(ts))
+}
+
+func TestNormalizeSemconvComponentFromOrigin(t *testing.T) {
+	ts := &info.TagStats{}
+	s := newTestSpan()
+	s.Meta["_dd.origin"] = "lambda"
+	assert.NoError(t, normalize(ts, s))
+	assert.Equal(t, "lambda", s.Meta["component"])
+	assert.Equal(t, noIssues(), statsToIssues(ts))
+}
+
+func TestNormalizeSemconvComponentNotOverwritten(t *testing.T) {
+	ts := &info.TagStats{}
+	s := newTestSpan()
+	s.Meta["component"] = "net/http"
+	s.Meta["_dd.origin"] = "lambda"
+	assert.NoError(t, normalize(ts, s))
+	assert.Equal(t, "net/http", s.Meta["component"])
+	assert.Equal(t, noIssues(), statsToIssues(ts))
+}
+
+func TestNormalizeTagRenames(t *testing.T) {
+	defer SetNormalizationConfig(nil) // restore defaults
+
+	cfg := defaultNormalizationConfig()
+	cfg.TagRenames = map[string]string{"peer.hostname": "network.destination.name"}
+	SetNormalizationConfig(cfg)
+
+	ts := &info.TagStats{}
+	s := newTestSpan()
+	s.Meta["peer.hostname"] = "cheese.internal"
+	assert.NoError(t, normalize(ts, s))
+	assert.Equal(t, "cheese.internal", s.Meta["network.destination.name"])
+	assert.NotContains(t, s.Meta, "peer.hostname")
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// The following types cover the subset of the OTLP/JSON trace export
+// request (https://github.com/open-telemetry/opentelemetry-proto) that the
+// agent needs in order to build a pb.Span. int64 fields that OTLP transmits
+// as JSON strings (to avoid precision loss on 53-bit JSON numbers) are kept
+// as strings here and parsed explicitly.
+type otlpExportTraceServiceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpKindNames maps the OTLP Span.SpanKind enum to the same kind names
+// used by Zipkin, so both paths can share spanTypeFromKind.
+var otlpKindNames = map[int]string{
+	2: "SERVER",
+	3: "CLIENT",
+	4: "PRODUCER",
+	5: "CONSUMER",
+}
+
+// otlpAttr looks up the string value of a resource/span attribute by key.
+func otlpAttr(attrs []otlpKeyValue, key string) string {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value.StringValue
+		}
+	}
+	return ""
+}
+
+// otlpToSpan translates a single OTLP span into a pb.Span, folding its hex
+// trace/span/parent IDs into the agent's uint64 ID model.
+func otlpToSpan(ts *info.TagStats, o otlpSpan, service string) (*pb.Span, error) {
+	traceID, truncated, err := foldHexID(o.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("traceId: %v", err)
+	}
+	if truncated {
+		ts.TracesMalformed.TraceIDTruncated128bit++
+	}
+	spanID, _, err := foldHexID(o.SpanID)
+	if err != nil {
+		return nil, fmt.Errorf("spanId: %v", err)
+	}
+	var parentID uint64
+	if o.ParentSpanID != "" {
+		parentID, _, err = foldHexID(o.ParentSpanID)
+		if err != nil {
+			return nil, fmt.Errorf("parentSpanId: %v", err)
+		}
+	}
+
+	start, err := strconv.ParseInt(o.StartTimeUnixNano, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("startTimeUnixNano: %v", err)
+	}
+	var duration int64
+	if end, err := strconv.ParseInt(o.EndTimeUnixNano, 10, 64); err == nil {
+		duration = end - start
+	}
+
+	meta := make(map[string]string, len(o.Attributes))
+	for _, a := range o.Attributes {
+		meta[a.Key] = a.Value.StringValue
+	}
+
+	return &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Name:     o.Name,
+		Resource: o.Name,
+		Service:  service,
+		Start:    start,
+		Duration: duration,
+		Type:     spanTypeFromKind(otlpKindNames[o.Kind], tagsFromAttrs(o.Attributes)),
+		Meta:     meta,
+	}, nil
+}
+
+// tagsFromAttrs adapts OTLP attributes to the map[string]string shape that
+// spanTypeFromKind's CLIENT heuristics inspect.
+func tagsFromAttrs(attrs []otlpKeyValue) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = a.Value.StringValue
+	}
+	return m
+}
+
+// handleOTLPTraces implements POST /v1/traces: it accepts an OTLP/JSON
+// ExportTraceServiceRequest, translates each span into a pb.Span grouped by
+// trace ID, and funnels every resulting trace through normalizeTrace so the
+// same dropped/malformed accounting applies to OTLP traffic as to native one.
+func handleOTLPTraces(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var or otlpExportTraceServiceRequest
+	if err := json.NewDecoder(req.Body).Decode(&or); err != nil {
+		http.Error(w, fmt.Sprintf("decoding otlp trace request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ts := &info.TagStats{Lang: "otlp"}
+	traces := make(map[uint64]pb.Trace)
+	for _, rs := range or.ResourceSpans {
+		service := otlpAttr(rs.Resource.Attributes, "service.name")
+		for _, ss := range rs.ScopeSpans {
+			for _, os := range ss.Spans {
+				span, err := otlpToSpan(ts, os, service)
+				if err != nil {
+					continue
+				}
+				traces[span.TraceID] = append(traces[span.TraceID], span)
+			}
+		}
+	}
+	for _, t := range traces {
+		normalizeTrace(ts, t)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// RegisterZipkinAndOTLPHandlers mounts the Zipkin v2 JSON and OTLP/JSON
+// ingestion endpoints on mux, alongside the agent's native endpoints.
+func RegisterZipkinAndOTLPHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v2/spans", handleZipkinSpans)
+	mux.HandleFunc("/v1/traces", handleOTLPTraces)
+}
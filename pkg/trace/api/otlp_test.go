@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPToSpan(t *testing.T) {
+	ts := &info.TagStats{}
+	o := otlpSpan{
+		TraceID:           "00000000000004d2",
+		SpanID:            "0000000000000929",
+		Name:              "GET /raclette",
+		Kind:              2, // SERVER
+		StartTimeUnixNano: "1448466874000000000",
+		EndTimeUnixNano:   "1448466874010000000",
+		Attributes: []otlpKeyValue{
+			{Key: "http.status_code", Value: otlpAnyValue{StringValue: "200"}},
+		},
+	}
+
+	s, err := otlpToSpan(ts, o, "django")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1234), s.TraceID)
+	assert.Equal(t, uint64(2345), s.SpanID)
+	assert.Equal(t, "django", s.Service)
+	assert.Equal(t, "web", s.Type)
+	assert.Equal(t, int64(10000000), s.Duration)
+	assert.Equal(t, "200", s.Meta["http.status_code"])
+}
+
+func TestHandleOTLPTraces(t *testing.T) {
+	body := `{
+		"resourceSpans": [{
+			"resource": {"attributes": [{"key":"service.name","value":{"stringValue":"django"}}]},
+			"scopeSpans": [{
+				"spans": [{
+					"traceId": "00000000000004d2",
+					"spanId": "0000000000000929",
+					"name": "GET /raclette",
+					"kind": 2,
+					"startTimeUnixNano": "1448466874000000000",
+					"endTimeUnixNano": "1448466874010000000"
+				}]
+			}]
+		}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handleOTLPTraces(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestHandleOTLPTracesInvalidJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewBufferString("not json"))
+	w := httptest.NewRecorder()
+
+	handleOTLPTraces(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
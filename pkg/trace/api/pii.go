@@ -0,0 +1,99 @@
+package api
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// defaultPIIBudgetMicros bounds how long scrubPII may spend on a single
+// span before it bails out, so a pathological Meta map can't stall the
+// receiver's normalization loop.
+const defaultPIIBudgetMicros = 500
+
+// PIIRule is a single regex-based scrubbing rule: every match of Pattern in
+// a scrubbed field is replaced by Replacement.
+type PIIRule struct {
+	// Name identifies the rule, for logging and debugging purposes.
+	Name string `json:"name" yaml:"name"`
+	// Pattern is the regular expression matching the sensitive content.
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Replacement is substituted for every match of Pattern; it may refer
+	// to capture groups from Pattern (e.g. "$1=?").
+	Replacement string `json:"replacement" yaml:"replacement"`
+
+	re *regexp.Regexp
+}
+
+// defaultPIIRules catches the most common categories of sensitive data that
+// end up in span resources and tags by accident.
+func defaultPIIRules() []PIIRule {
+	rules := []PIIRule{
+		{Name: "credit_card", Pattern: `\b(?:\d[ -]?){13,16}\b`, Replacement: "?"},
+		{Name: "email", Pattern: `\b[\w.+-]+@[\w-]+\.[\w.-]+\b`, Replacement: "?"},
+		{Name: "jwt", Pattern: `\bey[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\b`, Replacement: "?"},
+		{Name: "bearer_token", Pattern: `(?i)\bbearer\s+[A-Za-z0-9._-]+`, Replacement: "Bearer ?"},
+		{Name: "query_secret", Pattern: `(?i)\b(password|api_key|token|secret)=[^&\s]+`, Replacement: "$1=?"},
+	}
+	for i := range rules {
+		rules[i].re = regexp.MustCompile(rules[i].Pattern)
+	}
+	return rules
+}
+
+// scrubPII applies conf's PII rules to s.Resource and the values of s.Meta,
+// in place, recording pii_redacted if anything was rewritten. It must run
+// before Resource/Meta are UTF-8 fixed up and truncated, so that a redacted
+// string is still subject to the usual length clamps.
+func scrubPII(ts *info.TagStats, conf *NormalizationConfig, s *pb.Span) {
+	if !conf.PIIScrubbing || len(conf.PIIRules) == 0 {
+		return
+	}
+	budget := time.Duration(conf.PIIBudgetMicros) * time.Microsecond
+	if budget <= 0 {
+		budget = defaultPIIBudgetMicros * time.Microsecond
+	}
+	deadline := time.Now().Add(budget)
+
+	redacted := false
+	if out, changed := scrubString(conf.PIIRules, s.Resource, deadline); changed {
+		s.Resource = out
+		redacted = true
+	}
+	for k, v := range s.Meta {
+		if time.Now().After(deadline) {
+			break
+		}
+		if out, changed := scrubString(conf.PIIRules, v, deadline); changed {
+			s.Meta[k] = out
+			redacted = true
+		}
+	}
+	if redacted {
+		ts.TracesMalformed.PIIRedacted++
+	}
+}
+
+// scrubString applies every rule in order to v, stopping early if deadline
+// has passed, and reports whether anything was replaced.
+func scrubString(rules []PIIRule, v string, deadline time.Time) (string, bool) {
+	if v == "" {
+		return v, false
+	}
+	changed := false
+	for _, r := range rules {
+		if r.re == nil {
+			continue
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		if out := r.re.ReplaceAllString(v, r.Replacement); out != v {
+			v = out
+			changed = true
+		}
+	}
+	return v, changed
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// semconvRenames maps legacy or integration-specific tag keys to their
+// canonical OpenTelemetry semantic-convention name, mirroring the tags that
+// dd-trace-go now stamps on every span. New integrations can register
+// additional mappings here without having to touch the normalizer itself.
+var semconvRenames = map[string]string{
+	"http.status":          "http.status_code",
+	"httpStatusCode":       "http.status_code",
+	"response.status_code": "http.status_code",
+	"db.type":              "db.system",
+}
+
+// semconvDBSystems is the set of recognized values for the db.system tag,
+// as defined by the OpenTelemetry semantic conventions.
+var semconvDBSystems = map[string]bool{
+	"postgresql":    true,
+	"mysql":         true,
+	"mssql":         true,
+	"oracle":        true,
+	"mongodb":       true,
+	"redis":         true,
+	"cassandra":     true,
+	"elasticsearch": true,
+	"sqlite":        true,
+	"memcached":     true,
+}
+
+// applySemanticConventions promotes legacy tag keys to their canonical
+// semantic-convention form, validates a handful of typed constraints on the
+// resulting tags, and infers a component tag from the span's origin when
+// one isn't already set. It must run after Meta has been fixed up for UTF-8,
+// as it mutates s.Meta in place.
+func applySemanticConventions(ts *info.TagStats, s *pb.Span) {
+	if len(s.Meta) == 0 {
+		return
+	}
+
+	for from, to := range semconvRenames {
+		v, ok := s.Meta[from]
+		delete(s.Meta, from)
+		if !ok {
+			continue
+		}
+		if _, exists := s.Meta[to]; !exists {
+			s.Meta[to] = v
+		}
+	}
+
+	if v, ok := s.Meta["http.status_code"]; ok && !isValidStatusCode(v) {
+		ts.TracesMalformed.SemconvInvalidHTTPStatus++
+		delete(s.Meta, "http.status_code")
+	}
+
+	if v, ok := s.Meta["db.system"]; ok && !semconvDBSystems[v] {
+		ts.TracesMalformed.SemconvInvalidDBSystem++
+		delete(s.Meta, "db.system")
+	}
+
+	if _, ok := s.Meta["component"]; !ok {
+		if origin := s.Meta["_dd.origin"]; origin != "" {
+			s.Meta["component"] = origin
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// zipkinSpan is the subset of the Zipkin v2 JSON span format that the agent
+// understands. See https://zipkin.io/zipkin-api/#/default/post_spans.
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	Timestamp     int64             `json:"timestamp"` // microseconds since epoch
+	Duration      int64             `json:"duration"`  // microseconds
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+// spanTypeFromKind maps a Zipkin/OTLP span kind to the agent's span type.
+// CLIENT spans are further refined by inspecting their tags, the same way
+// dd-trace-go's own Zipkin exporters infer a type.
+func spanTypeFromKind(kind string, tags map[string]string) string {
+	switch kind {
+	case "SERVER":
+		return "web"
+	case "PRODUCER", "CONSUMER":
+		return "queue"
+	case "CLIENT":
+		if _, ok := tags["sql.query"]; ok {
+			return "db"
+		}
+		if _, ok := tags["db.type"]; ok {
+			return "db"
+		}
+		if _, ok := tags["db.system"]; ok {
+			return "db"
+		}
+		return "http"
+	default:
+		return ""
+	}
+}
+
+// zipkinToSpan translates a single Zipkin v2 span into a pb.Span, folding
+// its hex trace/span/parent IDs into the agent's uint64 ID model.
+func zipkinToSpan(ts *info.TagStats, z zipkinSpan) (*pb.Span, error) {
+	traceID, truncated, err := foldHexID(z.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("traceId: %v", err)
+	}
+	if truncated {
+		ts.TracesMalformed.TraceIDTruncated128bit++
+	}
+	spanID, _, err := foldHexID(z.ID)
+	if err != nil {
+		return nil, fmt.Errorf("id: %v", err)
+	}
+	var parentID uint64
+	if z.ParentID != "" {
+		parentID, _, err = foldHexID(z.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("parentId: %v", err)
+		}
+	}
+
+	meta := make(map[string]string, len(z.Tags))
+	for k, v := range z.Tags {
+		meta[k] = v
+	}
+
+	return &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Name:     z.Name,
+		Resource: z.Name,
+		Service:  z.LocalEndpoint.ServiceName,
+		Start:    z.Timestamp * int64(time.Microsecond),
+		Duration: z.Duration * int64(time.Microsecond),
+		Type:     spanTypeFromKind(z.Kind, z.Tags),
+		Meta:     meta,
+	}, nil
+}
+
+// handleZipkinSpans implements POST /api/v2/spans: it accepts a JSON array
+// of Zipkin v2 spans, translates each into a pb.Span grouped by trace ID,
+// and funnels every resulting trace through normalizeTrace so the same
+// dropped/malformed accounting applies to Zipkin traffic as to native one.
+func handleZipkinSpans(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var zspans []zipkinSpan
+	if err := json.NewDecoder(req.Body).Decode(&zspans); err != nil {
+		http.Error(w, fmt.Sprintf("decoding zipkin spans: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ts := &info.TagStats{Lang: "zipkin"}
+	traces := make(map[uint64]pb.Trace)
+	for _, z := range zspans {
+		span, err := zipkinToSpan(ts, z)
+		if err != nil {
+			continue
+		}
+		traces[span.TraceID] = append(traces[span.TraceID], span)
+	}
+	for _, t := range traces {
+		normalizeTrace(ts, t)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
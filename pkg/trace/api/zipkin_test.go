@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZipkinToSpan(t *testing.T) {
+	ts := &info.TagStats{}
+	z := zipkinSpan{
+		TraceID:       "00000000000004d2",
+		ID:            "0000000000000929",
+		Name:          "GET /raclette",
+		Kind:          "SERVER",
+		Timestamp:     1448466874000000,
+		Duration:      10000,
+		LocalEndpoint: zipkinEndpoint{ServiceName: "django"},
+		Tags:          map[string]string{"http.status_code": "200"},
+	}
+
+	s, err := zipkinToSpan(ts, z)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1234), s.TraceID)
+	assert.Equal(t, uint64(2345), s.SpanID)
+	assert.Equal(t, "web", s.Type)
+	assert.Equal(t, "django", s.Service)
+	assert.Equal(t, "200", s.Meta["http.status_code"])
+	assert.Equal(t, int64(0), ts.TracesMalformed.TraceIDTruncated128bit)
+}
+
+func TestZipkinToSpanTruncated128bit(t *testing.T) {
+	ts := &info.TagStats{}
+	z := zipkinSpan{
+		TraceID: "00000000000000010000000000000929",
+		ID:      "0000000000000929",
+		Name:    "GET /raclette",
+	}
+
+	s, err := zipkinToSpan(ts, z)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x929), s.TraceID)
+	assert.Equal(t, int64(1), ts.TracesMalformed.TraceIDTruncated128bit)
+}
+
+func TestSpanTypeFromKind(t *testing.T) {
+	assert.Equal(t, "web", spanTypeFromKind("SERVER", nil))
+	assert.Equal(t, "queue", spanTypeFromKind("PRODUCER", nil))
+	assert.Equal(t, "queue", spanTypeFromKind("CONSUMER", nil))
+	assert.Equal(t, "db", spanTypeFromKind("CLIENT", map[string]string{"db.type": "postgresql"}))
+	assert.Equal(t, "http", spanTypeFromKind("CLIENT", nil))
+	assert.Equal(t, "", spanTypeFromKind("INTERNAL", nil))
+}
+
+func TestHandleZipkinSpans(t *testing.T) {
+	body := `[{"traceId":"00000000000004d2","id":"0000000000000929","name":"GET /raclette","kind":"SERVER","timestamp":1448466874000000,"duration":10000,"localEndpoint":{"serviceName":"django"}}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/spans", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handleZipkinSpans(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestHandleZipkinSpansInvalidJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/spans", bytes.NewBufferString("not json"))
+	w := httptest.NewRecorder()
+
+	handleZipkinSpans(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,22 @@
+package info
+
+// cardinalityProvider is registered by the cardinality limiter at init time
+// and polled by the agent status page to surface per (service, tag key)
+// distinct-value counters, keyed as returned by the provider.
+var cardinalityProvider func() map[string]uint64
+
+// RegisterCardinalityProvider registers f as the source of the cardinality
+// counters returned by CardinalityCounts. Only one provider is expected per
+// process; registering a new one replaces the last.
+func RegisterCardinalityProvider(f func() map[string]uint64) {
+	cardinalityProvider = f
+}
+
+// CardinalityCounts returns the per-key cardinality counters from the
+// registered provider, or nil if none has been registered.
+func CardinalityCounts() map[string]uint64 {
+	if cardinalityProvider == nil {
+		return nil
+	}
+	return cardinalityProvider()
+}
@@ -0,0 +1,101 @@
+// Package info exposes runtime statistics collected by the trace-agent,
+// surfaced on the agent status page and in telemetry.
+package info
+
+import "reflect"
+
+// TagStats groups the statistics of all the traces received by a tracer,
+// identified by its tags (language, interpreter, tracer version...).
+type TagStats struct {
+	// Lang specifies the language of the client sending these traces.
+	Lang string
+
+	// TracesDropped counts, by reason, the traces that were dropped entirely.
+	TracesDropped TracesDropped
+	// TracesMalformed counts, by reason, the traces that were fixed up but kept.
+	TracesMalformed TracesMalformed
+}
+
+// TracesDropped counts traces (or spans) dropped because they could not be
+// salvaged into a valid state.
+type TracesDropped struct {
+	// TraceIDZero counts spans dropped because their trace ID was zero.
+	TraceIDZero int64 `reason:"trace_id_zero"`
+	// SpanIDZero counts spans dropped because their span ID was zero.
+	SpanIDZero int64 `reason:"span_id_zero"`
+	// EmptyTrace counts traces dropped because they contained no spans.
+	EmptyTrace int64 `reason:"empty_trace"`
+	// ForeignSpan counts traces dropped because one of their spans had a
+	// trace ID that didn't match the rest of the trace.
+	ForeignSpan int64 `reason:"foreign_span"`
+}
+
+// TracesMalformed counts traces (or spans) that were found to be invalid in
+// some way but were normalized rather than dropped.
+type TracesMalformed struct {
+	// ServiceEmpty counts spans whose service name was empty and replaced.
+	ServiceEmpty int64 `reason:"service_empty"`
+	// ServiceTruncate counts spans whose service name was too long and truncated.
+	ServiceTruncate int64 `reason:"service_truncate"`
+	// SpanNameEmpty counts spans whose name was empty and replaced.
+	SpanNameEmpty int64 `reason:"span_name_empty"`
+	// SpanNameTruncate counts spans whose name was too long and truncated.
+	SpanNameTruncate int64 `reason:"span_name_truncate"`
+	// SpanNameInvalid counts spans whose name had no alphanumeric characters and was replaced.
+	SpanNameInvalid int64 `reason:"span_name_invalid"`
+	// ResourceEmpty counts spans whose resource was empty and replaced.
+	ResourceEmpty int64 `reason:"resource_empty"`
+	// InvalidStartDate counts spans whose start date looked invalid.
+	InvalidStartDate int64 `reason:"invalid_start_date"`
+	// InvalidDuration counts spans whose duration was negative and zeroed.
+	InvalidDuration int64 `reason:"invalid_duration"`
+	// TypeTruncate counts spans whose type was too long and truncated.
+	TypeTruncate int64 `reason:"type_truncate"`
+	// DuplicateSpanID counts spans whose ID collided with another span in the same trace.
+	DuplicateSpanID int64 `reason:"duplicate_span_id"`
+	// SemconvInvalidHTTPStatus counts spans whose http.status_code tag (after
+	// semantic-convention promotion) wasn't a valid HTTP status code.
+	SemconvInvalidHTTPStatus int64 `reason:"semconv_invalid_http_status"`
+	// SemconvInvalidDBSystem counts spans whose db.system tag (after
+	// semantic-convention promotion) wasn't a recognized database system.
+	SemconvInvalidDBSystem int64 `reason:"semconv_invalid_db_system"`
+	// PIIRedacted counts spans that had sensitive data redacted from their
+	// resource or tags by the PII scrubber.
+	PIIRedacted int64 `reason:"pii_redacted"`
+	// TraceIDTruncated128bit counts spans whose incoming 128-bit trace ID
+	// (from Zipkin or OTLP) had non-zero high bits that were discarded when
+	// folding it down to the agent's 64-bit trace ID model.
+	TraceIDTruncated128bit int64 `reason:"trace_id_truncated_128bit"`
+	// TagHighCardinality counts spans that had a tag value rewritten to the
+	// high-cardinality sentinel because its (service, tag key) pair exceeded
+	// its distinct-value budget.
+	TagHighCardinality int64 `reason:"tag_high_cardinality"`
+}
+
+// TagValues returns a map of {reason -> count} for every field tagged with a
+// `reason`, so that callers can report normalization issues without knowing
+// about each individual counter.
+func (d TracesDropped) TagValues() map[string]int64 {
+	return tagValues(d)
+}
+
+// TagValues returns a map of {reason -> count} for every field tagged with a
+// `reason`, so that callers can report normalization issues without knowing
+// about each individual counter.
+func (m TracesMalformed) TagValues() map[string]int64 {
+	return tagValues(m)
+}
+
+func tagValues(v interface{}) map[string]int64 {
+	val := reflect.ValueOf(v)
+	typ := val.Type()
+	out := make(map[string]int64, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		reason := typ.Field(i).Tag.Get("reason")
+		if reason == "" {
+			continue
+		}
+		out[reason] = val.Field(i).Int()
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+// Package pb holds the core data structures exchanged between tracers and
+// the trace-agent. These mirror the msgpack/protobuf wire format used by
+// the Datadog tracing libraries.
+package pb
+
+// Span represents a computed span, that is, a span that will be processed
+// and its duration is already set. It is enriched and sent by the tracing
+// libraries and normalized by the agent before being sent to the backend.
+type Span struct {
+	// Service is the name of the service with which this span is associated.
+	Service string `json:"service"`
+	// Name is the operation name of this span.
+	Name string `json:"name"`
+	// Resource is the resource name of this span, often more specific than Name.
+	Resource string `json:"resource"`
+	// TraceID is the ID of the trace to which this span belongs.
+	TraceID uint64 `json:"trace_id"`
+	// SpanID is the ID of this span.
+	SpanID uint64 `json:"span_id"`
+	// ParentID is the ID of this span's parent, or zero if this span has no parent.
+	ParentID uint64 `json:"parent_id"`
+	// Start is the number of nanoseconds between the Unix epoch and the start of this span.
+	Start int64 `json:"start"`
+	// Duration is the time length of this span in nanoseconds.
+	Duration int64 `json:"duration"`
+	// Error is 1 if there is an error associated with this span, 0 otherwise.
+	Error int32 `json:"error"`
+	// Meta is a mapping from tag name to tag value for string-valued tags.
+	Meta map[string]string `json:"meta,omitempty"`
+	// Metrics is a mapping from tag name to tag value for numeric-valued tags.
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	// Type is the type of the span (e.g. "web", "db", "cache").
+	Type string `json:"type"`
+}
+
+// Trace is a collection of spans that belong to the same trace.
+type Trace []*Span